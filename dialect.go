@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Dialect abstracts the SQL syntax differences between the database engines
+// this tool can export to/from: identifier quoting, placeholder syntax,
+// literal formatting, and upsert clauses.
+type Dialect interface {
+	// Name is the value accepted by --driver/DB_DRIVER that selects this dialect.
+	Name() string
+	// QuoteIdentifier quotes a table or column name.
+	QuoteIdentifier(name string) string
+	// Placeholder returns the bind-parameter placeholder for the i'th
+	// (1-indexed) argument of a query.
+	Placeholder(i int) string
+	// FormatBool renders a boolean as a literal usable in an INSERT statement.
+	FormatBool(b bool) string
+	// FormatTime renders a time.Time as a literal usable in an INSERT statement.
+	FormatTime(t time.Time) string
+	// UpsertClause returns the clause appended to an INSERT statement to make
+	// it idempotent on the given conflict columns, e.g. "ON CONFLICT (id) DO
+	// NOTHING" or "ON DUPLICATE KEY UPDATE id = id".
+	UpsertClause(conflictCols []string) string
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string { return "postgres" }
+func (postgresDialect) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+func (postgresDialect) Placeholder(i int) string { return fmt.Sprintf("$%d", i) }
+func (postgresDialect) FormatBool(b bool) string { return fmt.Sprintf("%t", b) }
+func (postgresDialect) FormatTime(t time.Time) string {
+	return "'" + t.UTC().Format("2006-01-02T15:04:05Z") + "'"
+}
+func (postgresDialect) UpsertClause(conflictCols []string) string {
+	if len(conflictCols) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("ON CONFLICT (%s) DO NOTHING", strings.Join(conflictCols, ", "))
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string { return "mysql" }
+func (mysqlDialect) QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+func (mysqlDialect) Placeholder(i int) string { return "?" }
+func (mysqlDialect) FormatBool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+func (mysqlDialect) FormatTime(t time.Time) string {
+	return "'" + t.UTC().Format("2006-01-02 15:04:05") + "'"
+}
+func (mysqlDialect) UpsertClause(conflictCols []string) string {
+	if len(conflictCols) == 0 {
+		return ""
+	}
+	col := conflictCols[0]
+	return fmt.Sprintf("ON DUPLICATE KEY UPDATE `%s` = `%s`", col, col)
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string { return "sqlite" }
+func (sqliteDialect) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+func (sqliteDialect) Placeholder(i int) string { return "?" }
+func (sqliteDialect) FormatBool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+func (sqliteDialect) FormatTime(t time.Time) string {
+	return "'" + t.UTC().Format("2006-01-02 15:04:05") + "'"
+}
+func (sqliteDialect) UpsertClause(conflictCols []string) string {
+	if len(conflictCols) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("ON CONFLICT (%s) DO NOTHING", strings.Join(conflictCols, ", "))
+}
+
+// dialectFor resolves a --driver/DB_DRIVER value to its Dialect. An empty
+// driver defaults to postgres to preserve existing behavior.
+func dialectFor(driver string) (Dialect, error) {
+	switch strings.ToLower(driver) {
+	case "", "postgres", "postgresql", "pq":
+		return postgresDialect{}, nil
+	case "mysql":
+		return mysqlDialect{}, nil
+	case "sqlite", "sqlite3":
+		return sqliteDialect{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported --driver %q (expected postgres, mysql, or sqlite)", driver)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDialectFor(t *testing.T) {
+	tests := []struct {
+		driver string
+		want   Dialect
+	}{
+		{"", postgresDialect{}},
+		{"postgres", postgresDialect{}},
+		{"postgresql", postgresDialect{}},
+		{"pq", postgresDialect{}},
+		{"Postgres", postgresDialect{}},
+		{"mysql", mysqlDialect{}},
+		{"MYSQL", mysqlDialect{}},
+		{"sqlite", sqliteDialect{}},
+		{"sqlite3", sqliteDialect{}},
+	}
+	for _, tt := range tests {
+		got, err := dialectFor(tt.driver)
+		if err != nil {
+			t.Fatalf("dialectFor(%q) returned error: %v", tt.driver, err)
+		}
+		if got != tt.want {
+			t.Fatalf("dialectFor(%q) = %#v, want %#v", tt.driver, got, tt.want)
+		}
+	}
+}
+
+func TestDialectForUnsupported(t *testing.T) {
+	if _, err := dialectFor("oracle"); err == nil {
+		t.Fatal("expected error for unsupported driver, got nil")
+	}
+}
+
+func TestQuoteIdentifierEscapesEmbeddedQuoteChar(t *testing.T) {
+	if got := (postgresDialect{}).QuoteIdentifier(`we"ird`); got != `"we""ird"` {
+		t.Fatalf("postgresDialect.QuoteIdentifier = %q", got)
+	}
+	if got := (mysqlDialect{}).QuoteIdentifier("we`ird"); got != "`we``ird`" {
+		t.Fatalf("mysqlDialect.QuoteIdentifier = %q", got)
+	}
+	if got := (sqliteDialect{}).QuoteIdentifier(`we"ird`); got != `"we""ird"` {
+		t.Fatalf("sqliteDialect.QuoteIdentifier = %q", got)
+	}
+}
+
+func TestPlaceholder(t *testing.T) {
+	if got := (postgresDialect{}).Placeholder(2); got != "$2" {
+		t.Fatalf("postgresDialect.Placeholder(2) = %q", got)
+	}
+	if got := (mysqlDialect{}).Placeholder(2); got != "?" {
+		t.Fatalf("mysqlDialect.Placeholder(2) = %q", got)
+	}
+	if got := (sqliteDialect{}).Placeholder(2); got != "?" {
+		t.Fatalf("sqliteDialect.Placeholder(2) = %q", got)
+	}
+}
+
+func TestFormatBool(t *testing.T) {
+	if got := (postgresDialect{}).FormatBool(true); got != "true" {
+		t.Fatalf("postgresDialect.FormatBool(true) = %q", got)
+	}
+	if got := (mysqlDialect{}).FormatBool(true); got != "1" {
+		t.Fatalf("mysqlDialect.FormatBool(true) = %q", got)
+	}
+	if got := (mysqlDialect{}).FormatBool(false); got != "0" {
+		t.Fatalf("mysqlDialect.FormatBool(false) = %q", got)
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if got := (postgresDialect{}).FormatTime(ts); got != "'2026-01-02T03:04:05Z'" {
+		t.Fatalf("postgresDialect.FormatTime = %q", got)
+	}
+	if got := (mysqlDialect{}).FormatTime(ts); got != "'2026-01-02 03:04:05'" {
+		t.Fatalf("mysqlDialect.FormatTime = %q", got)
+	}
+	if got := (sqliteDialect{}).FormatTime(ts); got != "'2026-01-02 03:04:05'" {
+		t.Fatalf("sqliteDialect.FormatTime = %q", got)
+	}
+}
+
+func TestUpsertClause(t *testing.T) {
+	if got := (postgresDialect{}).UpsertClause(nil); got != "" {
+		t.Fatalf("postgresDialect.UpsertClause(nil) = %q, want empty", got)
+	}
+	if got := (postgresDialect{}).UpsertClause([]string{"id"}); got != "ON CONFLICT (id) DO NOTHING" {
+		t.Fatalf("postgresDialect.UpsertClause = %q", got)
+	}
+	if got := (mysqlDialect{}).UpsertClause([]string{"id"}); got != "ON DUPLICATE KEY UPDATE `id` = `id`" {
+		t.Fatalf("mysqlDialect.UpsertClause = %q", got)
+	}
+	if got := (sqliteDialect{}).UpsertClause([]string{"id"}); got != "ON CONFLICT (id) DO NOTHING" {
+		t.Fatalf("sqliteDialect.UpsertClause = %q", got)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// outputFormat selects how rows are rendered to the dump: one INSERT per
+// row, batched multi-row INSERTs, or Postgres COPY blocks.
+type outputFormat string
+
+const (
+	formatInsert      outputFormat = "insert"
+	formatMultiInsert outputFormat = "multi-insert"
+	formatCopy        outputFormat = "copy"
+)
+
+func parseOutputFormat(s string) (outputFormat, error) {
+	switch outputFormat(s) {
+	case formatInsert, formatMultiInsert, formatCopy:
+		return outputFormat(s), nil
+	default:
+		return "", fmt.Errorf("unsupported --format %q (expected insert, multi-insert, or copy)", s)
+	}
+}
+
+// streamInsertStatements queries db and writes the result directly to w in
+// the requested format, without buffering the whole dump in memory. This is
+// the streaming counterpart to generateInsertStatements. If redact is
+// non-nil, its rules are applied to each scanned value before formatting.
+func streamInsertStatements(w io.Writer, db *sql.DB, dialect Dialect, query string, table string, param string, format outputFormat, batchSize int, redact *redactor) error {
+	rows, err := db.Query(query, param)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+
+	switch format {
+	case formatCopy:
+		return streamCopyFormat(bw, rows, cols, table, redact)
+	case formatMultiInsert:
+		return streamMultiInsert(bw, rows, cols, dialect, table, batchSize, redact)
+	default:
+		return streamInsert(bw, rows, cols, dialect, table, redact)
+	}
+}
+
+func streamInsert(w *bufio.Writer, rows *sql.Rows, cols []string, dialect Dialect, table string, redact *redactor) error {
+	for rows.Next() {
+		values, err := scanRowValues(rows, dialect, table, cols, redact)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "INSERT INTO %s (%s) VALUES (%s);\n", dialect.QuoteIdentifier(table), strings.Join(cols, ", "), strings.Join(values, ", "))
+	}
+	return rows.Err()
+}
+
+func streamMultiInsert(w *bufio.Writer, rows *sql.Rows, cols []string, dialect Dialect, table string, batchSize int, redact *redactor) error {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+
+	batch := make([]string, 0, batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "INSERT INTO %s (%s) VALUES %s;\n", dialect.QuoteIdentifier(table), strings.Join(cols, ", "), strings.Join(batch, ","))
+		batch = batch[:0]
+	}
+
+	for rows.Next() {
+		values, err := scanRowValues(rows, dialect, table, cols, redact)
+		if err != nil {
+			return err
+		}
+		batch = append(batch, fmt.Sprintf("(%s)", strings.Join(values, ", ")))
+		if len(batch) == batchSize {
+			flush()
+		}
+	}
+	flush()
+	return rows.Err()
+}
+
+func streamCopyFormat(w *bufio.Writer, rows *sql.Rows, cols []string, table string, redact *redactor) error {
+	quotedCols := make([]string, len(cols))
+	for i, c := range cols {
+		quotedCols[i] = `"` + strings.ReplaceAll(c, `"`, `""`) + `"`
+	}
+	fmt.Fprintf(w, "COPY \"%s\" (%s) FROM stdin;\n", table, strings.Join(quotedCols, ", "))
+
+	rawResult := make([]interface{}, len(cols))
+	dest := make([]interface{}, len(cols))
+	for i := range rawResult {
+		dest[i] = &rawResult[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+
+		fields := make([]string, len(cols))
+		for i, raw := range rawResult {
+			fields[i] = copyFieldLiteral(redact.apply(table, cols[i], raw))
+		}
+		w.WriteString(strings.Join(fields, "\t"))
+		w.WriteByte('\n')
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	w.WriteString("\\.\n")
+	return nil
+}
+
+// copyFieldLiteral renders a single column value per the Postgres COPY text
+// format: NULL becomes \N, and backslash, tab, and newline are backslash-escaped.
+func copyFieldLiteral(raw interface{}) string {
+	switch val := raw.(type) {
+	case nil:
+		return `\N`
+	case bool:
+		if val {
+			return "t"
+		}
+		return "f"
+	case []byte:
+		return escapeCopyField(string(val))
+	case time.Time:
+		return val.UTC().Format("2006-01-02 15:04:05Z")
+	default:
+		return escapeCopyField(fmt.Sprintf("%v", val))
+	}
+}
+
+func escapeCopyField(s string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		"\t", `\t`,
+		"\n", `\n`,
+		"\r", `\r`,
+	)
+	return replacer.Replace(s)
+}
+
+// scanRowValues scans the current row and renders each column as a SQL
+// literal suitable for an INSERT statement, using dialect for bool/time
+// formatting and quoting. If redact is non-nil, its rules are applied to
+// each column's value first.
+func scanRowValues(rows *sql.Rows, dialect Dialect, table string, cols []string, redact *redactor) ([]string, error) {
+	numCols := len(cols)
+	rawResult := make([]interface{}, numCols)
+	dest := make([]interface{}, numCols)
+	for i := range rawResult {
+		dest[i] = &rawResult[i]
+	}
+
+	if err := rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	values := make([]string, numCols)
+	for i, raw := range rawResult {
+		raw = redact.apply(table, cols[i], raw)
+		switch val := raw.(type) {
+		case nil:
+			values[i] = "NULL"
+		case bool:
+			values[i] = dialect.FormatBool(val)
+		case []byte:
+			values[i] = fmt.Sprintf("'%s'", escapeSingleQuotes(string(val)))
+		case string:
+			values[i] = fmt.Sprintf("'%s'", escapeSingleQuotes(val))
+		case time.Time:
+			values[i] = dialect.FormatTime(val)
+		default:
+			values[i] = fmt.Sprintf("'%v'", val)
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEscapeCopyField(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{`back\slash`, `back\\slash`},
+		{"tab\there", `tab\there`},
+		{"new\nline", `new\nline`},
+		{"carriage\rreturn", `carriage\rreturn`},
+		{"plain", "plain"},
+	}
+	for _, tt := range tests {
+		if got := escapeCopyField(tt.in); got != tt.want {
+			t.Errorf("escapeCopyField(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCopyFieldLiteral(t *testing.T) {
+	if got := copyFieldLiteral(nil); got != `\N` {
+		t.Errorf("copyFieldLiteral(nil) = %q, want \\N", got)
+	}
+	if got := copyFieldLiteral(true); got != "t" {
+		t.Errorf("copyFieldLiteral(true) = %q, want t", got)
+	}
+	if got := copyFieldLiteral(false); got != "f" {
+		t.Errorf("copyFieldLiteral(false) = %q, want f", got)
+	}
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if got := copyFieldLiteral(ts); got != "2026-01-02 03:04:05Z" {
+		t.Errorf("copyFieldLiteral(time) = %q", got)
+	}
+	if got := copyFieldLiteral([]byte("a\tb")); got != `a\tb` {
+		t.Errorf("copyFieldLiteral([]byte) = %q", got)
+	}
+	if got := copyFieldLiteral(42); got != "42" {
+		t.Errorf("copyFieldLiteral(42) = %q", got)
+	}
+}
+
+// openTestRows seeds an in-memory sqlite table with n rows of a single text
+// column and returns the *sql.Rows from querying it back, so the streaming
+// functions (which take a concrete *sql.Rows, not an interface) can be
+// exercised without a real export-target database.
+func openTestRows(t *testing.T, n int) (*sql.DB, *sql.Rows) {
+	t.Helper()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("opening in-memory sqlite db: %v", err)
+	}
+	if _, err := db.Exec("CREATE TABLE widgets (id TEXT)"); err != nil {
+		t.Fatalf("creating table: %v", err)
+	}
+	for i := 0; i < n; i++ {
+		if _, err := db.Exec("INSERT INTO widgets (id) VALUES (?)", "w"+string(rune('0'+i))); err != nil {
+			t.Fatalf("seeding row %d: %v", i, err)
+		}
+	}
+	rows, err := db.Query("SELECT id FROM widgets")
+	if err != nil {
+		t.Fatalf("querying rows: %v", err)
+	}
+	return db, rows
+}
+
+func TestStreamMultiInsertBatchBoundaries(t *testing.T) {
+	tests := []struct {
+		name       string
+		rowCount   int
+		batchSize  int
+		wantInsert int // number of "INSERT INTO" statements expected
+	}{
+		{"batchSizeOne", 3, 1, 3},
+		{"exactMultiple", 4, 2, 2},
+		{"remainderFlush", 5, 2, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, rows := openTestRows(t, tt.rowCount)
+			defer db.Close()
+			defer rows.Close()
+
+			var buf bytes.Buffer
+			w := bufio.NewWriter(&buf)
+			if err := streamMultiInsert(w, rows, []string{"id"}, postgresDialect{}, "widgets", tt.batchSize, nil); err != nil {
+				t.Fatalf("streamMultiInsert returned error: %v", err)
+			}
+			w.Flush()
+
+			got := strings.Count(buf.String(), "INSERT INTO")
+			if got != tt.wantInsert {
+				t.Fatalf("streamMultiInsert with %d rows batchSize=%d produced %d INSERT statements, want %d:\n%s", tt.rowCount, tt.batchSize, got, tt.wantInsert, buf.String())
+			}
+
+			totalTuples := strings.Count(buf.String(), "('w")
+			if totalTuples != tt.rowCount {
+				t.Fatalf("streamMultiInsert produced %d row tuples, want %d:\n%s", totalTuples, tt.rowCount, buf.String())
+			}
+		})
+	}
+}
+
+func TestStreamInsertOneStatementPerRow(t *testing.T) {
+	db, rows := openTestRows(t, 2)
+	defer db.Close()
+	defer rows.Close()
+
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	if err := streamInsert(w, rows, []string{"id"}, postgresDialect{}, "widgets", nil); err != nil {
+		t.Fatalf("streamInsert returned error: %v", err)
+	}
+	w.Flush()
+
+	if got := strings.Count(buf.String(), "INSERT INTO"); got != 2 {
+		t.Fatalf("streamInsert produced %d statements, want 2:\n%s", got, buf.String())
+	}
+}
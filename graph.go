@@ -0,0 +1,282 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+)
+
+// foreignKey describes a single FK edge discovered via schema introspection:
+// childTable.childColumn references parentTable.parentColumn.
+type foreignKey struct {
+	childTable   string
+	childColumn  string
+	parentTable  string
+	parentColumn string
+}
+
+// foreignKeyGraph maps a table to the FKs of other tables that point at it,
+// i.e. graph[parentTable] lists every child row that must be exported (and,
+// on import, inserted after) a row in parentTable.
+type foreignKeyGraph map[string][]foreignKey
+
+// buildForeignKeyGraph introspects the target database's schema and returns
+// the graph of foreign keys reachable from rootTable. Postgres and MySQL are
+// both queried via information_schema, but need different queries (MySQL's
+// key_column_usage already exposes referenced_table_name/column directly;
+// Postgres requires joining in constraint_column_usage to learn them).
+// sqlite has no information_schema so it is queried via PRAGMA
+// foreign_key_list instead.
+func buildForeignKeyGraph(db *sql.DB, dialect Dialect) (foreignKeyGraph, error) {
+	switch dialect.(type) {
+	case sqliteDialect:
+		return buildForeignKeyGraphSQLite(db)
+	case mysqlDialect:
+		return buildForeignKeyGraphMySQL(db)
+	default:
+		return buildForeignKeyGraphPostgres(db)
+	}
+}
+
+func buildForeignKeyGraphMySQL(db *sql.DB) (foreignKeyGraph, error) {
+	rows, err := db.Query(`
+		SELECT
+			kcu.table_name AS child_table,
+			kcu.column_name AS child_column,
+			kcu.referenced_table_name AS parent_table,
+			kcu.referenced_column_name AS parent_column
+		FROM information_schema.key_column_usage kcu
+		WHERE kcu.referenced_table_name IS NOT NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("introspecting foreign keys: %w", err)
+	}
+	defer rows.Close()
+
+	graph := make(foreignKeyGraph)
+	for rows.Next() {
+		var fk foreignKey
+		if err := rows.Scan(&fk.childTable, &fk.childColumn, &fk.parentTable, &fk.parentColumn); err != nil {
+			return nil, err
+		}
+		graph[fk.parentTable] = append(graph[fk.parentTable], fk)
+	}
+	return graph, rows.Err()
+}
+
+func buildForeignKeyGraphPostgres(db *sql.DB) (foreignKeyGraph, error) {
+	rows, err := db.Query(`
+		SELECT
+			tc.table_name AS child_table,
+			kcu.column_name AS child_column,
+			ccu.table_name AS parent_table,
+			ccu.column_name AS parent_column
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage kcu
+			ON tc.constraint_name = kcu.constraint_name
+			AND tc.table_schema = kcu.table_schema
+		JOIN information_schema.constraint_column_usage ccu
+			ON tc.constraint_name = ccu.constraint_name
+			AND tc.table_schema = ccu.table_schema
+		WHERE tc.constraint_type = 'FOREIGN KEY'
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("introspecting foreign keys: %w", err)
+	}
+	defer rows.Close()
+
+	graph := make(foreignKeyGraph)
+	for rows.Next() {
+		var fk foreignKey
+		if err := rows.Scan(&fk.childTable, &fk.childColumn, &fk.parentTable, &fk.parentColumn); err != nil {
+			return nil, err
+		}
+		graph[fk.parentTable] = append(graph[fk.parentTable], fk)
+	}
+	return graph, rows.Err()
+}
+
+func buildForeignKeyGraphSQLite(db *sql.DB) (foreignKeyGraph, error) {
+	tableRows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'table'`)
+	if err != nil {
+		return nil, fmt.Errorf("listing tables: %w", err)
+	}
+	var tables []string
+	for tableRows.Next() {
+		var name string
+		if err := tableRows.Scan(&name); err != nil {
+			tableRows.Close()
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	tableRows.Close()
+	if err := tableRows.Err(); err != nil {
+		return nil, err
+	}
+
+	graph := make(foreignKeyGraph)
+	for _, table := range tables {
+		fkRows, err := db.Query(fmt.Sprintf(`PRAGMA foreign_key_list(%s)`, table))
+		if err != nil {
+			return nil, fmt.Errorf("listing foreign keys for %s: %w", table, err)
+		}
+		for fkRows.Next() {
+			var id, seq int
+			var refTable, from, to string
+			var onUpdate, onDelete, match interface{}
+			if err := fkRows.Scan(&id, &seq, &refTable, &from, &to, &onUpdate, &onDelete, &match); err != nil {
+				fkRows.Close()
+				return nil, err
+			}
+			fk := foreignKey{childTable: table, childColumn: from, parentTable: refTable, parentColumn: to}
+			graph[fk.parentTable] = append(graph[fk.parentTable], fk)
+		}
+		fkRows.Close()
+		if err := fkRows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return graph, nil
+}
+
+// walkOptions constrains a graph traversal.
+type walkOptions struct {
+	maxDepth       int // 0 means unlimited
+	includeTables  map[string]bool
+	excludeTables  map[string]bool
+	followOverride map[string][]foreignKey // table -> extra edges to follow, from --follow "table.column"
+}
+
+// tableExport is one stop in the traversal: the table to query, the FK edge
+// it was reached through (its column and the parent table/column it
+// references), and how deep it sits from the root.
+type tableExport struct {
+	table        string
+	parentTable  string
+	childColumn  string
+	parentColumn string
+	depth        int
+}
+
+// walkForeignKeyGraph performs a breadth-first traversal of graph starting
+// at rootTable, returning the reachable tables in the order they should be
+// queried/inserted so that a replay never violates a foreign key (parents
+// before children). opts.followOverride[parentTable] lists "column" entries
+// (from --follow "table.column") that are stitched onto the graph as extra
+// edges pointing at parentTable, for schemas missing a formal FK constraint.
+func walkForeignKeyGraph(graph foreignKeyGraph, rootTable string, rootColumn string, opts walkOptions) []tableExport {
+	visited := map[string]bool{rootTable: true}
+	queue := []tableExport{{table: rootTable, parentColumn: rootColumn, depth: 0}}
+	var order []tableExport
+
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if cur.table != rootTable {
+			order = append(order, cur)
+		}
+
+		if opts.maxDepth > 0 && cur.depth >= opts.maxDepth {
+			continue
+		}
+
+		children := append([]foreignKey{}, graph[cur.table]...)
+		for _, edge := range opts.followOverride[cur.table] {
+			children = append(children, edge)
+		}
+		sort.Slice(children, func(i, j int) bool { return children[i].childTable < children[j].childTable })
+
+		for _, fk := range children {
+			if visited[fk.childTable] {
+				continue
+			}
+			if len(opts.includeTables) > 0 && !opts.includeTables[fk.childTable] {
+				continue
+			}
+			if opts.excludeTables[fk.childTable] {
+				continue
+			}
+			visited[fk.childTable] = true
+			queue = append(queue, tableExport{
+				table:        fk.childTable,
+				parentTable:  cur.table,
+				childColumn:  fk.childColumn,
+				parentColumn: fk.parentColumn,
+				depth:        cur.depth + 1,
+			})
+		}
+	}
+
+	return order
+}
+
+// buildQueriesForWalk turns a BFS walk into one SELECT per table, each
+// joined back through its ancestor chain to rootTable so it can be filtered
+// by the single root id parameter (e.g. users.id). The root table's own row
+// is always included too (walkForeignKeyGraph's order never contains it),
+// so the caller gets a complete table set without having to special-case it.
+func buildQueriesForWalk(order []tableExport, rootTable string, rootColumn string, dialect Dialect) map[string]string {
+	byTable := make(map[string]tableExport, len(order))
+	for _, t := range order {
+		byTable[t.table] = t
+	}
+
+	queries := make(map[string]string, len(order)+1)
+	queries[rootTable] = fmt.Sprintf("SELECT %s.* FROM %s WHERE %s.%s = %s",
+		dialect.QuoteIdentifier(rootTable), dialect.QuoteIdentifier(rootTable),
+		dialect.QuoteIdentifier(rootTable), rootColumn, dialect.Placeholder(1))
+
+	for _, t := range order {
+		// Walk from t up to the root, emitting one JOIN per hop in the order
+		// the tables are introduced (t's immediate parent first, then its
+		// parent's parent, and so on) so every JOIN's ON clause only
+		// references tables already named earlier in the FROM/JOIN chain.
+		var joins []string
+		child := t
+		for {
+			joins = append(joins, fmt.Sprintf(
+				"JOIN %s ON %s.%s = %s.%s",
+				dialect.QuoteIdentifier(child.parentTable),
+				dialect.QuoteIdentifier(child.table),
+				child.childColumn,
+				dialect.QuoteIdentifier(child.parentTable),
+				child.parentColumn,
+			))
+			if child.parentTable == rootTable {
+				break
+			}
+			child = byTable[child.parentTable]
+		}
+
+		query := fmt.Sprintf("SELECT %s.* FROM %s", dialect.QuoteIdentifier(t.table), dialect.QuoteIdentifier(t.table))
+		query += " " + joinStrings(joins)
+		query += fmt.Sprintf(" WHERE %s.%s = %s", dialect.QuoteIdentifier(rootTable), rootColumn, dialect.Placeholder(1))
+		queries[t.table] = query
+	}
+	return queries
+}
+
+func joinStrings(parts []string) string {
+	out := parts[0]
+	for _, p := range parts[1:] {
+		out += " " + p
+	}
+	return out
+}
+
+// stringSliceFlag implements flag.Value for repeatable string flags such as
+// --include-table and --exclude-table.
+type stringSliceFlag []string
+
+func (s *stringSliceFlag) String() string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprint([]string(*s))
+}
+
+func (s *stringSliceFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestParseFollowFlags(t *testing.T) {
+	overrides := parseFollowFlags([]string{"payments.user_id", "notes.owner_id"})
+
+	got := overrides["users"]
+	sort.Slice(got, func(i, j int) bool { return got[i].childTable < got[j].childTable })
+
+	want := []foreignKey{
+		{childTable: "notes", childColumn: "owner_id", parentTable: "users", parentColumn: "id"},
+		{childTable: "payments", childColumn: "user_id", parentTable: "users", parentColumn: "id"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseFollowFlags overrides[users] = %#v, want %#v", got, want)
+	}
+}
+
+func TestParseFollowFlagsIgnoresMalformed(t *testing.T) {
+	overrides := parseFollowFlags([]string{"no-dot-here", "payments.user_id"})
+	if len(overrides["users"]) != 1 {
+		t.Fatalf("expected malformed entry to be skipped, got overrides = %#v", overrides)
+	}
+}
+
+func TestWalkForeignKeyGraphOrdersParentsBeforeChildren(t *testing.T) {
+	graph := foreignKeyGraph{
+		"users":    {{childTable: "accounts", childColumn: "user_id", parentTable: "users", parentColumn: "id"}},
+		"accounts": {{childTable: "payments", childColumn: "account_id", parentTable: "accounts", parentColumn: "id"}},
+	}
+
+	order := walkForeignKeyGraph(graph, "users", "id", walkOptions{})
+
+	var tables []string
+	for _, t := range order {
+		tables = append(tables, t.table)
+	}
+	if !reflect.DeepEqual(tables, []string{"accounts", "payments"}) {
+		t.Fatalf("walkForeignKeyGraph order = %v, want [accounts payments]", tables)
+	}
+}
+
+// TestBuildQueriesForWalkIncludesRootTable guards against the root table
+// (users) being dropped from the follow-graph query map: walkForeignKeyGraph
+// deliberately excludes the root from its returned order, so it's
+// buildQueriesForWalk's job to still add a query for it.
+func TestBuildQueriesForWalkIncludesRootTable(t *testing.T) {
+	graph := foreignKeyGraph{
+		"users": {{childTable: "accounts", childColumn: "user_id", parentTable: "users", parentColumn: "id"}},
+	}
+	order := walkForeignKeyGraph(graph, "users", "id", walkOptions{})
+
+	queries := buildQueriesForWalk(order, "users", "id", postgresDialect{})
+
+	if _, ok := queries["users"]; !ok {
+		t.Fatalf("buildQueriesForWalk result is missing the root table's own query: %#v", queries)
+	}
+	if _, ok := queries["accounts"]; !ok {
+		t.Fatalf("buildQueriesForWalk result is missing accounts: %#v", queries)
+	}
+	if !strings.Contains(queries["users"], "WHERE") {
+		t.Fatalf("root table query should be filtered by the root id parameter, got %q", queries["users"])
+	}
+}
+
+func TestWalkForeignKeyGraphRespectsMaxDepth(t *testing.T) {
+	graph := foreignKeyGraph{
+		"users":    {{childTable: "accounts", childColumn: "user_id", parentTable: "users", parentColumn: "id"}},
+		"accounts": {{childTable: "payments", childColumn: "account_id", parentTable: "accounts", parentColumn: "id"}},
+	}
+
+	order := walkForeignKeyGraph(graph, "users", "id", walkOptions{maxDepth: 1})
+
+	var tables []string
+	for _, t := range order {
+		tables = append(tables, t.table)
+	}
+	if !reflect.DeepEqual(tables, []string{"accounts"}) {
+		t.Fatalf("walkForeignKeyGraph with maxDepth=1 order = %v, want [accounts]", tables)
+	}
+}
+
+func TestWalkForeignKeyGraphExcludeAndIncludeTables(t *testing.T) {
+	graph := foreignKeyGraph{
+		"users": {
+			{childTable: "accounts", childColumn: "user_id", parentTable: "users", parentColumn: "id"},
+			{childTable: "sessions", childColumn: "user_id", parentTable: "users", parentColumn: "id"},
+		},
+	}
+
+	excluded := walkForeignKeyGraph(graph, "users", "id", walkOptions{excludeTables: map[string]bool{"sessions": true}})
+	if len(excluded) != 1 || excluded[0].table != "accounts" {
+		t.Fatalf("exclude-table did not prune sessions, got %#v", excluded)
+	}
+
+	included := walkForeignKeyGraph(graph, "users", "id", walkOptions{includeTables: map[string]bool{"accounts": true}})
+	if len(included) != 1 || included[0].table != "accounts" {
+		t.Fatalf("include-table did not restrict to accounts, got %#v", included)
+	}
+}
+
+func TestWalkForeignKeyGraphFollowOverride(t *testing.T) {
+	graph := foreignKeyGraph{}
+	opts := walkOptions{followOverride: parseFollowFlags([]string{"payments.user_id"})}
+
+	order := walkForeignKeyGraph(graph, "users", "id", opts)
+	if len(order) != 1 || order[0].table != "payments" {
+		t.Fatalf("followOverride did not stitch in payments, got %#v", order)
+	}
+}
@@ -0,0 +1,404 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// onConflictMode controls how a replayed INSERT reacts to a row that
+// already exists in the target database.
+type onConflictMode string
+
+const (
+	onConflictFail   onConflictMode = "fail"
+	onConflictSkip   onConflictMode = "skip"
+	onConflictUpdate onConflictMode = "update"
+)
+
+// insertStmtPattern matches one statement emitted by the export side's
+// --format=insert or --format=multi-insert: INSERT INTO "table"/`table` (cols) VALUES (...);
+// The table name may be double-quoted (Postgres/sqlite) or backtick-quoted
+// (MySQL) depending on the --driver the dump was generated with.
+var insertStmtPattern = regexp.MustCompile("(?is)^INSERT INTO\\s+[\"`]?([A-Za-z0-9_]+)[\"`]?\\s*\\(([^)]*)\\)\\s*VALUES\\s*(.+);\\s*$")
+
+// runImport implements the `import` subcommand: replay a previously
+// generated .sql dump against a target database inside a single
+// transaction, with conflict handling and optional id remapping.
+func runImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	driverFlag := fs.String("driver", "", "Database driver to use: postgres, mysql, or sqlite (default postgres; overrides DB_DRIVER)")
+	onConflictFlag := fs.String("on-conflict", string(onConflictFail), "How to handle rows that already exist: skip, update, or fail")
+	idRemapFlag := fs.String("id-remap", "", "Old id to replace with a freshly generated UUID in every column the target database's own foreign-key graph identifies as referencing it (plus the row's own \"id\"). Does NOT touch free-text or JSON columns that might happen to contain the same value verbatim.")
+	dryRunFlag := fs.Bool("dry-run", false, "Apply inside a transaction, report row counts per table, then roll back instead of committing")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		log.Fatal("Usage: account-importer import [--driver=...] [--on-conflict=skip|update|fail] [--id-remap=<old-id>] [--dry-run] <dump.sql>")
+	}
+	dumpPath := fs.Arg(0)
+
+	onConflict := onConflictMode(*onConflictFlag)
+	switch onConflict {
+	case onConflictFail, onConflictSkip, onConflictUpdate:
+	default:
+		log.Fatalf("Invalid --on-conflict %q (expected skip, update, or fail)", *onConflictFlag)
+	}
+
+	dialect, err := dialectFor(driverOrEnv(*driverFlag))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dump, err := os.ReadFile(dumpPath)
+	if err != nil {
+		log.Fatalf("Failed to read dump %s: %v", dumpPath, err)
+	}
+
+	statements, err := parseDumpStatements(string(dump))
+	if err != nil {
+		log.Fatalf("Failed to parse dump %s: %v", dumpPath, err)
+	}
+
+	db := connectDB(dialect, false)
+	defer db.Close()
+
+	if *idRemapFlag != "" {
+		newID, err := newUUIDv4()
+		if err != nil {
+			log.Fatalf("Failed to generate replacement id: %v", err)
+		}
+		graph, err := buildForeignKeyGraph(db, dialect)
+		if err != nil {
+			log.Fatalf("Failed to introspect foreign keys for --id-remap: %v", err)
+		}
+		statements = remapID(statements, *idRemapFlag, newID, idColumnsFromGraph(graph))
+		log.Printf("Remapping id %s -> %s", *idRemapFlag, newID)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Fatalf("Failed to start transaction: %v", err)
+	}
+
+	rowCounts := make(map[string]int)
+	for _, stmt := range statements {
+		table, execStmt, rowCount := prepareStatement(stmt, dialect, onConflict)
+		if _, err := tx.Exec(execStmt); err != nil {
+			tx.Rollback()
+			log.Fatalf("Failed to apply statement for table %s: %v\n%s", table, err, execStmt)
+		}
+		rowCounts[table] += rowCount
+	}
+
+	if *dryRunFlag {
+		if err := tx.Rollback(); err != nil {
+			log.Fatalf("Failed to roll back dry run: %v", err)
+		}
+		fmt.Println("Dry run (rolled back). Row counts per table:")
+	} else {
+		if err := tx.Commit(); err != nil {
+			log.Fatalf("Failed to commit import: %v", err)
+		}
+		fmt.Println("Import applied. Row counts per table:")
+	}
+	for _, table := range sortedTableNames(rowCounts) {
+		fmt.Printf("  %s: %d\n", table, rowCounts[table])
+	}
+}
+
+// driverOrEnv mirrors resolveDriver for the import subcommand's own flag set.
+func driverOrEnv(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	return os.Getenv("DB_DRIVER")
+}
+
+// parseDumpStatements splits a dump into its individual INSERT statements,
+// skipping comment lines (export writes "-- Insert for <table>" headers)
+// and blank lines. COPY-format blocks are converted back into INSERTs so
+// they can be replayed the same way, since database/sql has no portable way
+// to issue a COPY ... FROM stdin through a generic driver.
+func parseDumpStatements(dump string) ([]string, error) {
+	var statements []string
+	scanner := bufio.NewScanner(strings.NewReader(dump))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "--"):
+			continue
+		case strings.HasPrefix(line, "COPY "):
+			copyStatements, err := parseCopyBlock(line, scanner)
+			if err != nil {
+				return nil, err
+			}
+			statements = append(statements, copyStatements...)
+		default:
+			statements = append(statements, line)
+		}
+	}
+	return statements, scanner.Err()
+}
+
+var copyHeaderPattern = regexp.MustCompile(`(?i)^COPY\s+"?([A-Za-z0-9_]+)"?\s*\(([^)]*)\)\s*FROM\s+stdin;$`)
+
+func parseCopyBlock(header string, scanner *bufio.Scanner) ([]string, error) {
+	m := copyHeaderPattern.FindStringSubmatch(header)
+	if m == nil {
+		return nil, fmt.Errorf("malformed COPY header: %s", header)
+	}
+	table, cols := m[1], m[2]
+
+	var statements []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == `\.` {
+			return statements, nil
+		}
+		fields := strings.Split(line, "\t")
+		values := make([]string, len(fields))
+		for i, f := range fields {
+			if f == `\N` {
+				values[i] = "NULL"
+			} else {
+				values[i] = fmt.Sprintf("'%s'", escapeSingleQuotes(unescapeCopyField(f)))
+			}
+		}
+		statements = append(statements, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s);", table, cols, strings.Join(values, ", ")))
+	}
+	return nil, fmt.Errorf("COPY block for %s missing terminating \\.", table)
+}
+
+func unescapeCopyField(s string) string {
+	replacer := strings.NewReplacer(`\t`, "\t", `\n`, "\n", `\r`, "\r", `\\`, `\`)
+	return replacer.Replace(s)
+}
+
+// prepareStatement rewrites a plain "INSERT INTO table (...) VALUES (...);"
+// with the conflict-handling clause for onConflict, and returns the table
+// name and row count (1, or len(VALUES tuples) for a multi-insert batch) it
+// contributes for --dry-run reporting.
+func prepareStatement(stmt string, dialect Dialect, onConflict onConflictMode) (table string, execStmt string, rowCount int) {
+	m := insertStmtPattern.FindStringSubmatch(stmt)
+	if m == nil {
+		return "", stmt, 0
+	}
+	table, cols, values := m[1], m[2], m[3]
+	rowCount = len(splitValueTuples(values))
+
+	clause := conflictClause(dialect, strings.Split(cols, ","), onConflict)
+	trimmed := strings.TrimSuffix(strings.TrimSpace(stmt), ";")
+	if clause == "" {
+		return table, trimmed + ";", rowCount
+	}
+	return table, trimmed + " " + clause + ";", rowCount
+}
+
+// conflictClause returns the dialect-specific clause appended to an INSERT
+// to implement --on-conflict, assuming "id" is the table's primary key (the
+// convention every table in this schema follows).
+func conflictClause(dialect Dialect, cols []string, onConflict onConflictMode) string {
+	switch onConflict {
+	case onConflictSkip:
+		return dialect.UpsertClause([]string{"id"})
+	case onConflictUpdate:
+		return updateClause(dialect, cols)
+	default:
+		return ""
+	}
+}
+
+func updateClause(dialect Dialect, cols []string) string {
+	var setCols []string
+	for _, c := range cols {
+		c = strings.TrimSpace(c)
+		if c == "id" {
+			continue
+		}
+		setCols = append(setCols, c)
+	}
+	if len(setCols) == 0 {
+		return dialect.UpsertClause([]string{"id"})
+	}
+
+	switch dialect.(type) {
+	case mysqlDialect:
+		var assignments []string
+		for _, c := range setCols {
+			assignments = append(assignments, fmt.Sprintf("`%s` = VALUES(`%s`)", c, c))
+		}
+		return "ON DUPLICATE KEY UPDATE " + strings.Join(assignments, ", ")
+	default:
+		var assignments []string
+		for _, c := range setCols {
+			assignments = append(assignments, fmt.Sprintf("%s = EXCLUDED.%s", c, c))
+		}
+		return "ON CONFLICT (id) DO UPDATE SET " + strings.Join(assignments, ", ")
+	}
+}
+
+// remapID replaces the quoted old id with the quoted new id, but only in
+// values that fall under a column idColumns (built from the target
+// database's own introspected foreign-key graph by idColumnsFromGraph, the
+// same graph --follow-graph walks on export) identifies as storing a
+// reference to another row's id. A blind ReplaceAll across the whole
+// statement would also rewrite the old id if it happened to appear inside
+// an unrelated free-text or JSON column, silently corrupting that row
+// instead of remapping a reference to it; restricting the substitution to
+// columns the schema itself says are id references avoids that. Statements
+// that don't match insertStmtPattern (so their column names aren't known)
+// are left untouched.
+func remapID(statements []string, oldID, newID string, idColumns map[string]map[string]bool) []string {
+	quotedOld := "'" + oldID + "'"
+	quotedNew := "'" + newID + "'"
+
+	out := make([]string, len(statements))
+	for i, stmt := range statements {
+		m := insertStmtPattern.FindStringSubmatch(stmt)
+		if m == nil {
+			out[i] = stmt
+			continue
+		}
+		table := m[1]
+		cols := splitSQLList(m[2])
+		valuesBlob := m[3]
+
+		var newTuples []string
+		for _, tuple := range splitValueTuples(valuesBlob) {
+			fields := splitSQLList(strings.TrimSuffix(strings.TrimPrefix(tuple, "("), ")"))
+			if len(fields) == len(cols) {
+				for j, col := range cols {
+					if isIDColumn(table, strings.TrimSpace(col), idColumns) && fields[j] == quotedOld {
+						fields[j] = quotedNew
+					}
+				}
+			}
+			newTuples = append(newTuples, "("+strings.Join(fields, ", ")+")")
+		}
+
+		out[i] = strings.Replace(stmt, valuesBlob, strings.Join(newTuples, ","), 1)
+	}
+	return out
+}
+
+// idColumnsFromGraph flattens a foreignKeyGraph (as returned by
+// buildForeignKeyGraph) into the set of columns, per table, that are known
+// via introspected FK constraints to reference another row's id.
+func idColumnsFromGraph(graph foreignKeyGraph) map[string]map[string]bool {
+	cols := make(map[string]map[string]bool)
+	for _, edges := range graph {
+		for _, fk := range edges {
+			if cols[fk.childTable] == nil {
+				cols[fk.childTable] = make(map[string]bool)
+			}
+			cols[fk.childTable][fk.childColumn] = true
+		}
+	}
+	return cols
+}
+
+// isIDColumn reports whether table.col is a column known to store a row id:
+// either the table's own primary key (every table in this schema uses "id",
+// the same assumption conflictClause relies on) or a foreign key column per
+// idColumns.
+func isIDColumn(table, col string, idColumns map[string]map[string]bool) bool {
+	return col == "id" || idColumns[table][col]
+}
+
+// splitValueTuples splits a VALUES clause's body into its individual
+// "(...)"-delimited row tuples. Tracks quote state and paren depth rather
+// than splitting on the literal substring "),(", so a value containing that
+// exact text (e.g. "coords: (1,2),(3,4)") doesn't get torn in half.
+func splitValueTuples(blob string) []string {
+	var tuples []string
+	var cur strings.Builder
+	inQuote := false
+	depth := 0
+	runes := []rune(blob)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\'' && inQuote && i+1 < len(runes) && runes[i+1] == '\'':
+			cur.WriteRune(r)
+			cur.WriteRune(runes[i+1])
+			i++
+		case r == '\'':
+			inQuote = !inQuote
+			cur.WriteRune(r)
+		case r == '(' && !inQuote:
+			depth++
+			cur.WriteRune(r)
+		case r == ')' && !inQuote:
+			depth--
+			cur.WriteRune(r)
+			if depth == 0 {
+				tuples = append(tuples, cur.String())
+				cur.Reset()
+			}
+		case r == ',' && !inQuote && depth == 0:
+			// Separator between tuples; nothing to accumulate.
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	return tuples
+}
+
+// splitSQLList splits a comma-separated list of column names or value
+// literals, treating a comma inside a single-quoted literal (with ”
+// as an escaped quote, per escapeSingleQuotes) as part of the value rather
+// than a separator.
+func splitSQLList(s string) []string {
+	var fields []string
+	var cur strings.Builder
+	inQuote := false
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\'' && inQuote && i+1 < len(runes) && runes[i+1] == '\'':
+			cur.WriteRune(r)
+			cur.WriteRune(runes[i+1])
+			i++
+		case r == '\'':
+			inQuote = !inQuote
+			cur.WriteRune(r)
+		case r == ',' && !inQuote:
+			fields = append(fields, strings.TrimSpace(cur.String()))
+			cur.Reset()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	fields = append(fields, strings.TrimSpace(cur.String()))
+	return fields
+}
+
+// newUUIDv4 generates a random RFC 4122 version 4 UUID.
+func newUUIDv4() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+func sortedTableNames(counts map[string]int) []string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestPrepareStatementRoundTrip verifies that an INSERT statement emitted by
+// the export side (via dialect.QuoteIdentifier) for each supported dialect
+// is correctly recognized by insertStmtPattern/prepareStatement on the way
+// back in, including application of the --on-conflict clause. This guards
+// against regex changes only being exercised against one dialect's quoting.
+func TestPrepareStatementRoundTrip(t *testing.T) {
+	dialects := []Dialect{postgresDialect{}, mysqlDialect{}, sqliteDialect{}}
+
+	for _, dialect := range dialects {
+		t.Run(dialect.Name(), func(t *testing.T) {
+			stmt := "INSERT INTO " + dialect.QuoteIdentifier("accounts") + " (id, user_id) VALUES ('a1', 'u1');"
+
+			table, execStmt, rowCount := prepareStatement(stmt, dialect, onConflictSkip)
+			if table != "accounts" {
+				t.Fatalf("prepareStatement(%q) table = %q, want %q", stmt, table, "accounts")
+			}
+			if rowCount != 1 {
+				t.Fatalf("prepareStatement(%q) rowCount = %d, want 1", stmt, rowCount)
+			}
+			if execStmt == stmt {
+				t.Fatalf("prepareStatement(%q) did not append an --on-conflict clause", stmt)
+			}
+		})
+	}
+}
+
+func TestPrepareStatementMultiInsertRowCount(t *testing.T) {
+	stmt := "INSERT INTO `accounts` (id, user_id) VALUES ('a1', 'u1'),('a2', 'u1'),('a3', 'u1');"
+
+	table, _, rowCount := prepareStatement(stmt, mysqlDialect{}, onConflictFail)
+	if table != "accounts" {
+		t.Fatalf("table = %q, want %q", table, "accounts")
+	}
+	if rowCount != 3 {
+		t.Fatalf("rowCount = %d, want 3", rowCount)
+	}
+}
+
+func TestPrepareStatementUnrecognized(t *testing.T) {
+	table, execStmt, rowCount := prepareStatement("SELECT 1;", postgresDialect{}, onConflictSkip)
+	if table != "" || rowCount != 0 || execStmt != "SELECT 1;" {
+		t.Fatalf("prepareStatement on a non-INSERT statement should pass it through unchanged, got table=%q execStmt=%q rowCount=%d", table, execStmt, rowCount)
+	}
+}
+
+func TestRemapID(t *testing.T) {
+	statements := []string{
+		"INSERT INTO \"users\" (id) VALUES ('old-id');",
+		"INSERT INTO \"accounts\" (id, user_id) VALUES ('a1', 'old-id');",
+	}
+	idColumns := idColumnsFromGraph(foreignKeyGraph{
+		"users": {{childTable: "accounts", childColumn: "user_id", parentTable: "users", parentColumn: "id"}},
+	})
+	out := remapID(statements, "old-id", "new-id", idColumns)
+	for _, stmt := range out {
+		if strings.Contains(stmt, "old-id") {
+			t.Fatalf("remapID left old id in statement: %q", stmt)
+		}
+		if !strings.Contains(stmt, "new-id") {
+			t.Fatalf("remapID did not introduce new id in statement: %q", stmt)
+		}
+	}
+}
+
+// TestRemapIDFollowsGraphNotNamingConvention verifies the remap is driven by
+// the introspected FK graph rather than a "*_id" naming guess: a
+// non-conventionally-named FK column the graph knows about gets remapped,
+// while a "*_id"-named column the graph does NOT know about (e.g. it isn't
+// actually a foreign key, just named like one) is left alone.
+func TestRemapIDFollowsGraphNotNamingConvention(t *testing.T) {
+	statements := []string{
+		"INSERT INTO \"users\" (id) VALUES ('old-id');",
+		"INSERT INTO \"widgets\" (id, owner, tracking_id) VALUES ('w1', 'old-id', 'old-id');",
+	}
+	idColumns := idColumnsFromGraph(foreignKeyGraph{
+		"users": {{childTable: "widgets", childColumn: "owner", parentTable: "users", parentColumn: "id"}},
+	})
+	out := remapID(statements, "old-id", "new-id", idColumns)
+
+	if !strings.Contains(out[1], "'w1', 'new-id', 'old-id'") {
+		t.Fatalf("expected owner (a real FK per the graph) to be remapped and tracking_id (not in the graph) left alone, got: %q", out[1])
+	}
+}
+
+// TestRemapIDLeavesFreeTextColumnsAlone guards against the id remap
+// cascading into a column that isn't itself an id reference but happens to
+// contain the same literal value, e.g. an audit-log message quoting the id.
+func TestRemapIDLeavesFreeTextColumnsAlone(t *testing.T) {
+	statements := []string{
+		"INSERT INTO \"users\" (id) VALUES ('old-id');",
+		"INSERT INTO \"audit_logs\" (id, user_id, message) VALUES ('l1', 'old-id', 'touched row old-id');",
+	}
+	idColumns := idColumnsFromGraph(foreignKeyGraph{
+		"users": {{childTable: "audit_logs", childColumn: "user_id", parentTable: "users", parentColumn: "id"}},
+	})
+	out := remapID(statements, "old-id", "new-id", idColumns)
+
+	if strings.Contains(out[1], "'old-id', 'touched row old-id'") == false && strings.Contains(out[1], "touched row old-id") == false {
+		t.Fatalf("expected free-text message column to retain the old id, got: %q", out[1])
+	}
+	if !strings.Contains(out[1], "new-id") {
+		t.Fatalf("expected user_id column to be remapped, got: %q", out[1])
+	}
+	if strings.Contains(out[1], "'new-id', 'touched row new-id'") {
+		t.Fatalf("remapID rewrote the free-text message column, got: %q", out[1])
+	}
+}
+
+// TestSplitValueTuplesIgnoresParensInsideQuotedValues guards against
+// splitting on the literal substring "),(" when it appears inside a quoted
+// value rather than between two tuples.
+func TestSplitValueTuplesIgnoresParensInsideQuotedValues(t *testing.T) {
+	blob := "('r1','old-id','coords: (1,2),(3,4)'),('r2','old-id','plain text')"
+	tuples := splitValueTuples(blob)
+	if len(tuples) != 2 {
+		t.Fatalf("splitValueTuples returned %d tuples, want 2: %#v", len(tuples), tuples)
+	}
+	if tuples[0] != "('r1','old-id','coords: (1,2),(3,4)')" {
+		t.Fatalf("tuples[0] = %q", tuples[0])
+	}
+	if tuples[1] != "('r2','old-id','plain text')" {
+		t.Fatalf("tuples[1] = %q", tuples[1])
+	}
+}
+
+func TestPrepareStatementRowCountIgnoresParensInsideQuotedValues(t *testing.T) {
+	stmt := "INSERT INTO \"notes\" (id, user_id, body) VALUES ('r1', 'u1', 'coords: (1,2),(3,4)'), ('r2', 'u1', 'plain text');"
+	_, _, rowCount := prepareStatement(stmt, postgresDialect{}, onConflictFail)
+	if rowCount != 2 {
+		t.Fatalf("rowCount = %d, want 2", rowCount)
+	}
+}
+
+func TestParseDumpStatementsSkipsCommentsAndBlankLines(t *testing.T) {
+	dump := "-- Insert for accounts\nINSERT INTO \"accounts\" (id) VALUES ('a1');\n\n-- Insert for users\nINSERT INTO \"users\" (id) VALUES ('u1');\n"
+	statements, err := parseDumpStatements(dump)
+	if err != nil {
+		t.Fatalf("parseDumpStatements returned error: %v", err)
+	}
+	if len(statements) != 2 {
+		t.Fatalf("parseDumpStatements returned %d statements, want 2: %v", len(statements), statements)
+	}
+}
+
+func TestParseDumpStatementsCopyBlock(t *testing.T) {
+	dump := "COPY accounts (id, user_id) FROM stdin;\na1\tu1\na2\t\\N\n\\.\n"
+	statements, err := parseDumpStatements(dump)
+	if err != nil {
+		t.Fatalf("parseDumpStatements returned error: %v", err)
+	}
+	if len(statements) != 2 {
+		t.Fatalf("parseDumpStatements returned %d statements, want 2: %v", len(statements), statements)
+	}
+	if !strings.Contains(statements[1], "NULL") {
+		t.Fatalf("expected \\N to be converted to NULL, got %q", statements[1])
+	}
+}
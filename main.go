@@ -4,65 +4,135 @@ import (
 	"database/sql"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net/url"
 	"os"
 	"sort"
 	"strings"
-	"time"
 
+	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 var (
 	outputToFile  = flag.Bool("output", false, "Write SQL to a .sql file")
 	userIDFlag    = flag.String("user_id", "", "Specify a user_id directly")
 	accountIDFlag = flag.String("account_id", "", "Specify an account_id to look up user_id")
+	driverFlag    = flag.String("driver", "", "Database driver to use: postgres, mysql, or sqlite (default postgres; overrides DB_DRIVER)")
+
+	followGraphFlag = flag.Bool("follow-graph", false, "Discover related tables via the foreign-key graph rooted at users.id instead of the fixed table list")
+	maxDepthFlag    = flag.Int("max-depth", 0, "Maximum foreign-key hops to follow from users.id (0 = unlimited); only applies with --follow-graph")
+
+	includeTableFlag stringSliceFlag
+	excludeTableFlag stringSliceFlag
+	followFlag       stringSliceFlag
+
+	formatFlag    = flag.String("format", "insert", "Output format: insert, multi-insert, or copy")
+	batchSizeFlag = flag.Int("batch-size", 1000, "Rows per statement with --format=multi-insert")
+
+	redactConfigFlag = flag.String("redact-config", "", "Path to a JSON redaction config ({\"table\": {\"column\": \"transform\"}})")
+	redactFlag       stringSliceFlag
 )
 
-// Entry point of the script
+func init() {
+	flag.Var(&includeTableFlag, "include-table", "Restrict the --follow-graph walk to this table (repeatable)")
+	flag.Var(&excludeTableFlag, "exclude-table", "Prune this table from the --follow-graph walk (repeatable)")
+	flag.Var(&followFlag, "follow", `Force-follow an additional "table.column" edge not declared as a real FK constraint (repeatable)`)
+	flag.Var(&redactFlag, "redact", `Redact a column as it streams to output, e.g. "accounts.email=hash" (repeatable)`)
+}
+
+// Entry point: dispatches to the "export" (default, for backwards
+// compatibility with invocations that omit a subcommand) or "import"
+// subcommand.
 func main() {
-	flag.Parse()
+	args := os.Args[1:]
+	subcommand := "export"
+	if len(args) > 0 {
+		switch args[0] {
+		case "export", "import":
+			subcommand = args[0]
+			args = args[1:]
+		}
+	}
+
+	switch subcommand {
+	case "import":
+		runImport(args)
+	default:
+		runExport(args)
+	}
+}
+
+// runExport implements the `export` subcommand (the tool's original and
+// default behavior): dump a user's rows as INSERT/COPY statements.
+func runExport(args []string) {
+	flag.CommandLine.Parse(args)
 
 	if (*userIDFlag != "" && *accountIDFlag != "") || (len(flag.Args()) > 0 && (*userIDFlag != "" || *accountIDFlag != "")) {
 		log.Fatal("Provide either --user_id or --account_id, not both. Or pass a positional argument (assumed to be user_id by default).")
 	}
 
+	dialect, err := dialectFor(resolveDriver())
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	var userID string
-	var err error
 
 	switch {
 	case *userIDFlag != "":
 		userID = *userIDFlag
-		if !userExists(userID) {
+		if !userExists(dialect, userID) {
 			log.Fatalf("Could not find user_id %s in the database.", userID)
 		}
 	case *accountIDFlag != "":
-		userID, err = getUserIDFromAccountID(*accountIDFlag)
+		userID, err = getUserIDFromAccountID(dialect, *accountIDFlag)
 		if err != nil {
 			log.Fatalf("Could not find user_id associated with account_id %s: %v", *accountIDFlag, err)
 		}
 	case len(flag.Args()) == 1:
 		userID = flag.Args()[0]
-		if !userExists(userID) {
+		if !userExists(dialect, userID) {
 			log.Fatalf("Could not find user_id %s in the database.", userID)
 		}
 	default:
-		log.Fatal("Usage: go run main.go [--output=true] [--user_id=<id> | --account_id=<id>] <user_id>")
+		log.Fatal("Usage: go run main.go [export] [--output=true] [--driver=postgres|mysql|sqlite] [--user_id=<id> | --account_id=<id>] <user_id>\n       go run main.go import [--driver=...] [--on-conflict=skip|update|fail] [--id-remap=<old-id>] [--dry-run] <dump.sql>")
+	}
+
+	format, err := parseOutputFormat(*formatFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	redactCfg, err := buildRedactionConfig(*redactConfigFlag, []string(redactFlag))
+	if err != nil {
+		log.Fatal(err)
 	}
+	redact := newRedactor(redactCfg, userID)
 
-	db := connectDB()
+	db := connectDB(dialect, true)
 	defer db.Close()
 
-	queries := map[string]string{
-		"accounts":         "SELECT * FROM accounts WHERE user_id = $1",
-		"users":            "SELECT * FROM users WHERE id = $1",
-		"app_auth_tokens":  "SELECT * FROM app_auth_tokens WHERE user_id = $1",
-		"user_identities":  "SELECT * FROM user_identities WHERE user_id = $1",
-		"user_preferences": "SELECT * FROM user_preferences WHERE user_id = $1",
+	queries, err := tableQueries(db, dialect)
+	if err != nil {
+		log.Fatalf("Failed to determine tables to export: %v", err)
+	}
+
+	var file *os.File
+	writers := []io.Writer{os.Stdout}
+	if *outputToFile {
+		filename := fmt.Sprintf("user_%s_dump.sql", userID)
+		file, err = os.Create(filename)
+		if err != nil {
+			log.Fatalf("Failed to create output file: %v", err)
+		}
+		defer file.Close()
+		writers = append(writers, file)
 	}
+	w := io.MultiWriter(writers...)
 
-	var output strings.Builder
 	sortedKeys := make([]string, 0, len(queries))
 	for k := range queries {
 		sortedKeys = append(sortedKeys, k)
@@ -70,35 +140,102 @@ func main() {
 	sort.Strings(sortedKeys)
 
 	for _, table := range sortedKeys {
-		dump, err := generateInsertStatements(db, queries[table], table, userID)
-		if err != nil {
+		fmt.Fprintf(w, "-- Insert for %s\n", table)
+		if err := streamInsertStatements(w, db, dialect, queries[table], table, userID, format, *batchSizeFlag, redact); err != nil {
 			log.Printf("Warning: Skipping table %s due to error: %v", table, err)
 			continue
 		}
-		output.WriteString(fmt.Sprintf("-- Insert for %s\n", table))
-		output.WriteString(dump)
-		output.WriteString("\n")
+		fmt.Fprintln(w)
 	}
 
-	fmt.Print(output.String())
+	if file != nil {
+		fmt.Printf("Wrote SQL output to %s\n", file.Name())
+	}
+}
 
-	if *outputToFile {
-		filename := fmt.Sprintf("user_%s_dump.sql", userID)
-		err := os.WriteFile(filename, []byte(output.String()), 0644)
-		if err != nil {
-			log.Fatalf("Failed to write to file: %v", err)
+// tableQueries returns the table -> SELECT query map to export. By default
+// it's the fixed set of five tables keyed on user_id/id. With
+// --follow-graph it instead introspects the schema's foreign keys and walks
+// the graph rooted at users.id, per --max-depth/--include-table/
+// --exclude-table/--follow.
+func tableQueries(db *sql.DB, dialect Dialect) (map[string]string, error) {
+	if !*followGraphFlag {
+		tables := []string{"accounts", "users", "app_auth_tokens", "user_identities", "user_preferences"}
+		queries := make(map[string]string, len(tables))
+		for _, table := range tables {
+			column := "user_id"
+			if table == "users" {
+				column = "id"
+			}
+			queries[table] = fmt.Sprintf("SELECT * FROM %s WHERE %s = %s", table, column, dialect.Placeholder(1))
+		}
+		return queries, nil
+	}
+
+	graph, err := buildForeignKeyGraph(db, dialect)
+	if err != nil {
+		return nil, fmt.Errorf("building foreign-key graph: %w", err)
+	}
+
+	opts := walkOptions{
+		maxDepth:       *maxDepthFlag,
+		includeTables:  toSet(includeTableFlag),
+		excludeTables:  toSet(excludeTableFlag),
+		followOverride: parseFollowFlags([]string(followFlag)),
+	}
+
+	order := walkForeignKeyGraph(graph, "users", "id", opts)
+	return buildQueriesForWalk(order, "users", "id", dialect), nil
+}
+
+// parseFollowFlags turns repeated --follow "table.column" entries into
+// synthetic foreign keys pointing at users, for edges the schema doesn't
+// declare as a real FK constraint.
+func parseFollowFlags(follows []string) map[string][]foreignKey {
+	overrides := make(map[string][]foreignKey)
+	for _, f := range follows {
+		parts := strings.SplitN(f, ".", 2)
+		if len(parts) != 2 {
+			log.Printf("Warning: ignoring malformed --follow %q, expected \"table.column\"", f)
+			continue
 		}
-		fmt.Printf("Wrote SQL output to %s\n", filename)
+		overrides["users"] = append(overrides["users"], foreignKey{
+			childTable:   parts[0],
+			childColumn:  parts[1],
+			parentTable:  "users",
+			parentColumn: "id",
+		})
+	}
+	return overrides
+}
+
+func toSet(values stringSliceFlag) map[string]bool {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
 	}
+	return set
+}
+
+// resolveDriver returns the --driver flag value, falling back to DB_DRIVER.
+func resolveDriver() string {
+	if *driverFlag != "" {
+		return *driverFlag
+	}
+	return os.Getenv("DB_DRIVER")
 }
 
 // Retrieves user_id from an account_id by querying the DB
-func getUserIDFromAccountID(accountID string) (string, error) {
-	db := connectDB()
+func getUserIDFromAccountID(dialect Dialect, accountID string) (string, error) {
+	db := connectDB(dialect, true)
 	defer db.Close()
 
 	var userID string
-	err := db.QueryRow("SELECT user_id FROM accounts WHERE id = $1", accountID).Scan(&userID)
+	query := fmt.Sprintf("SELECT user_id FROM accounts WHERE id = %s", dialect.Placeholder(1))
+	err := db.QueryRow(query, accountID).Scan(&userID)
 	if err != nil {
 		return "", err
 	}
@@ -106,23 +243,50 @@ func getUserIDFromAccountID(accountID string) (string, error) {
 }
 
 // Verifies if the user_id exists in the users table
-func userExists(userID string) bool {
-	db := connectDB()
+func userExists(dialect Dialect, userID string) bool {
+	db := connectDB(dialect, true)
 	defer db.Close()
 
 	var id string
-	err := db.QueryRow("SELECT id FROM users WHERE id = $1", userID).Scan(&id)
+	query := fmt.Sprintf("SELECT id FROM users WHERE id = %s", dialect.Placeholder(1))
+	err := db.QueryRow(query, userID).Scan(&id)
 	if err != nil {
 		fmt.Println(err)
 	}
 	return err == nil
 }
 
-// Connects to Postgres DB using env vars
-// Connects to Postgres DB using env vars
-func connectDB() *sql.DB {
-	dbURL := os.Getenv("DATABASE_URL")
-	if dbURL == "" {
+// Connects to the target database using env vars. The driver/DSN shape is
+// selected by dialect: postgres and mysql read DATABASE_URL or the
+// DB_HOST/DB_PORT/DB_USER/DB_PASS/DB_NAME/DB_SSLMODE vars, sqlite reads
+// DB_NAME as a file path (or DATABASE_URL as a full "file:" DSN). readOnly
+// enforces Postgres' default_transaction_read_only guard for the export
+// path; the import path passes false since it needs to write.
+func connectDB(dialect Dialect, readOnly bool) *sql.DB {
+	driverName, dbURL := dbURLFor(dialect, readOnly)
+
+	db, err := sql.Open(driverName, dbURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return db
+}
+
+// dbURLFor builds the database/sql driver name and DSN for the given dialect.
+func dbURLFor(dialect Dialect, readOnly bool) (driverName string, dsn string) {
+	if dbURL := os.Getenv("DATABASE_URL"); dbURL != "" {
+		return driverNameFor(dialect), dbURL
+	}
+
+	switch dialect.(type) {
+	case sqliteDialect:
+		dbname := os.Getenv("DB_NAME")
+		if dbname == "" {
+			log.Fatal("Missing required DB environment variable DB_NAME (path to the sqlite file), or set DATABASE_URL")
+		}
+		return "sqlite3", dbname
+	default:
 		host := os.Getenv("DB_HOST")
 		port := os.Getenv("DB_PORT")
 		user := os.Getenv("DB_USER")
@@ -136,72 +300,36 @@ func connectDB() *sql.DB {
 			log.Fatal("Missing required DB environment variables. Either set DATABASE_URL or DB_HOST, DB_PORT, DB_USER, DB_NAME (and optionally DB_PASS and DB_SSLMODE)")
 		}
 
-		u := &url.URL{
-			Scheme: "postgres",
-			User:   url.UserPassword(user, pass),
-			Host:   fmt.Sprintf("%s:%s", host, port),
-			Path:   dbname,
-			RawQuery: url.Values{
-				"sslmode":                       []string{sslmode},
-				"default_transaction_read_only": []string{"true"},
-			}.Encode(),
-		}
-		dbURL = u.String()
-	}
-
-	db, err := sql.Open("postgres", dbURL)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	return db
-}
-
-// Generates INSERT statements for a table given the user_id parameter
-func generateInsertStatements(db *sql.DB, query string, table string, param string) (string, error) {
-	rows, err := db.Query(query, param)
-	if err != nil {
-		return "", err
-	}
-	defer rows.Close()
-
-	cols, err := rows.Columns()
-	if err != nil {
-		return "", err
-	}
-
-	var out strings.Builder
-	for rows.Next() {
-		rawResult := make([]interface{}, len(cols))
-		dest := make([]interface{}, len(cols))
-		for i := range rawResult {
-			dest[i] = &rawResult[i]
+		if _, ok := dialect.(mysqlDialect); ok {
+			return "mysql", fmt.Sprintf("%s:%s@tcp(%s:%s)/%s", user, pass, host, port, dbname)
 		}
 
-		if err := rows.Scan(dest...); err != nil {
-			return "", err
+		query := url.Values{"sslmode": []string{sslmode}}
+		if readOnly {
+			query.Set("default_transaction_read_only", "true")
 		}
-
-		values := make([]string, len(cols))
-		for i, raw := range rawResult {
-			switch val := raw.(type) {
-			case nil:
-				values[i] = "NULL"
-			case bool:
-				values[i] = fmt.Sprintf("%t", val)
-			case []byte:
-				values[i] = fmt.Sprintf("'%s'", escapeSingleQuotes(string(val)))
-			case time.Time:
-				values[i] = fmt.Sprintf("'%s'", val.UTC().Format("2006-01-02T15:04:05Z"))
-			default:
-				values[i] = fmt.Sprintf("'%v'", val)
-			}
+		u := &url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(user, pass),
+			Host:     fmt.Sprintf("%s:%s", host, port),
+			Path:     dbname,
+			RawQuery: query.Encode(),
 		}
-
-		out.WriteString(fmt.Sprintf("INSERT INTO \"%s\" (%s) VALUES (%s);\n", table, strings.Join(cols, ", "), strings.Join(values, ", ")))
+		return "postgres", u.String()
 	}
+}
 
-	return out.String(), nil
+// driverNameFor maps a Dialect to the registered database/sql driver name to
+// use when DATABASE_URL is already a full DSN.
+func driverNameFor(dialect Dialect) string {
+	switch dialect.(type) {
+	case mysqlDialect:
+		return "mysql"
+	case sqliteDialect:
+		return "sqlite3"
+	default:
+		return "postgres"
+	}
 }
 
 // Escapes single quotes in string values for SQL safety
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// redactionKind is the transform applied to a single column's values.
+type redactionKind string
+
+const (
+	redactHash       redactionKind = "hash"
+	redactNull       redactionKind = "null"
+	redactFakerEmail redactionKind = "faker:email"
+	redactFakerName  redactionKind = "faker:name"
+	redactRegex      redactionKind = "regex"
+)
+
+// redactionRule is the transform for one "table.column" plus any parameters
+// it needs (the salt for hash, or the pattern/replacement for regex).
+type redactionRule struct {
+	kind        redactionKind
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// redactionConfig maps table -> column -> the rule to apply to that column's
+// values as they stream to output.
+type redactionConfig map[string]map[string]redactionRule
+
+// redactSalt salts the hash transform; overridable via --redact-salt so two
+// exports of the same data don't produce comparable hashes without it.
+var redactSaltFlag = flag.String("redact-salt", "", "Salt used by --redact ...=hash (defaults to unsalted SHA-256 if unset)")
+
+// parseRedactFlags turns repeated --redact "table.column=transform" flags
+// into a redactionConfig. Supported transforms: hash, null, faker:email,
+// faker:name, and regex:<delim>pattern<delim>replacement<delim> (sed-style,
+// see parseRedactionTransform).
+func parseRedactFlags(specs []string) (redactionConfig, error) {
+	cfg := make(redactionConfig)
+	for _, spec := range specs {
+		tableCol, transform, ok := strings.Cut(spec, "=")
+		if !ok {
+			return nil, fmt.Errorf("malformed --redact %q, expected \"table.column=transform\"", spec)
+		}
+		table, column, ok := strings.Cut(tableCol, ".")
+		if !ok {
+			return nil, fmt.Errorf("malformed --redact %q, expected \"table.column=transform\"", spec)
+		}
+
+		rule, err := parseRedactionTransform(transform)
+		if err != nil {
+			return nil, fmt.Errorf("--redact %q: %w", spec, err)
+		}
+
+		if cfg[table] == nil {
+			cfg[table] = make(map[string]redactionRule)
+		}
+		cfg[table][column] = rule
+	}
+	return cfg, nil
+}
+
+func parseRedactionTransform(transform string) (redactionRule, error) {
+	switch {
+	case transform == string(redactHash):
+		return redactionRule{kind: redactHash}, nil
+	case transform == string(redactNull):
+		return redactionRule{kind: redactNull}, nil
+	case transform == string(redactFakerEmail):
+		return redactionRule{kind: redactFakerEmail}, nil
+	case transform == string(redactFakerName):
+		return redactionRule{kind: redactFakerName}, nil
+	case strings.HasPrefix(transform, "regex:"):
+		return parseRegexTransform(strings.TrimPrefix(transform, "regex:"))
+	default:
+		return redactionRule{}, fmt.Errorf("unknown transform %q (expected hash, null, faker:email, faker:name, or regex:<delim>pattern<delim>replacement<delim>)", transform)
+	}
+}
+
+// parseRegexTransform parses a sed-style "s///"-shaped regex spec:
+// <delim>pattern<delim>replacement<delim>, where <delim> is whatever
+// character immediately follows "regex:" (its final trailing occurrence is
+// optional). Splitting on a fixed "/" would break on any pattern that needs
+// to match a literal "/" itself (a URL path, a date like YYYY/MM/DD); letting
+// the caller pick a delimiter that doesn't collide with their pattern avoids
+// that ambiguity entirely, e.g. "regex:#^/v1/(.*)$#REDACTED#".
+func parseRegexTransform(spec string) (redactionRule, error) {
+	if len(spec) < 2 {
+		return redactionRule{}, fmt.Errorf("regex transform must be \"regex:<delim>pattern<delim>replacement<delim>\", e.g. \"regex:/^foo$/bar/\"")
+	}
+	delim := spec[:1]
+	rest := spec[1:]
+
+	parts := strings.SplitN(rest, delim, 2)
+	if len(parts) != 2 {
+		return redactionRule{}, fmt.Errorf("regex transform must be \"regex:<delim>pattern<delim>replacement<delim>\", e.g. \"regex:/^foo$/bar/\"")
+	}
+	pattern := parts[0]
+	replacement := strings.TrimSuffix(parts[1], delim)
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return redactionRule{}, fmt.Errorf("compiling regex: %w", err)
+	}
+	return redactionRule{kind: redactRegex, pattern: re, replacement: replacement}, nil
+}
+
+// redactConfigFile is the on-disk JSON shape for --redact-config, e.g.:
+//
+//	{"accounts": {"email": "hash"}, "users": {"phone": "null"}}
+type redactConfigFile map[string]map[string]string
+
+// loadRedactConfigFile reads a JSON redaction config and merges it into cfg
+// (flag-supplied rules win on conflicts, since they're applied second in
+// buildRedactionConfig).
+func loadRedactConfigFile(path string) (redactionConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading --redact-config %s: %w", path, err)
+	}
+
+	var raw redactConfigFile
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing --redact-config %s: %w", path, err)
+	}
+
+	cfg := make(redactionConfig)
+	for table, columns := range raw {
+		cfg[table] = make(map[string]redactionRule, len(columns))
+		for column, transform := range columns {
+			rule, err := parseRedactionTransform(transform)
+			if err != nil {
+				return nil, fmt.Errorf("--redact-config %s: table %s column %s: %w", path, table, column, err)
+			}
+			cfg[table][column] = rule
+		}
+	}
+	return cfg, nil
+}
+
+// buildRedactionConfig merges a --redact-config file (if any) with repeated
+// --redact flags, with the flags taking precedence for the same column.
+func buildRedactionConfig(configPath string, flagSpecs []string) (redactionConfig, error) {
+	cfg := make(redactionConfig)
+	if configPath != "" {
+		fileCfg, err := loadRedactConfigFile(configPath)
+		if err != nil {
+			return nil, err
+		}
+		for table, columns := range fileCfg {
+			cfg[table] = columns
+		}
+	}
+
+	flagCfg, err := parseRedactFlags(flagSpecs)
+	if err != nil {
+		return nil, err
+	}
+	for table, columns := range flagCfg {
+		if cfg[table] == nil {
+			cfg[table] = make(map[string]redactionRule)
+		}
+		for column, rule := range columns {
+			cfg[table][column] = rule
+		}
+	}
+	return cfg, nil
+}
+
+// redactor applies a redactionConfig to scanned row values before they're
+// formatted for output, pseudonymizing faker:* values deterministically from
+// the row's userID so the same user always maps to the same fake data.
+type redactor struct {
+	cfg    redactionConfig
+	userID string
+}
+
+func newRedactor(cfg redactionConfig, userID string) *redactor {
+	if len(cfg) == 0 {
+		return nil
+	}
+	return &redactor{cfg: cfg, userID: userID}
+}
+
+// apply returns the (possibly transformed) value for table.column, or raw
+// unchanged if no rule applies.
+func (r *redactor) apply(table, column string, raw interface{}) interface{} {
+	if r == nil {
+		return raw
+	}
+	rule, ok := r.cfg[table][column]
+	if !ok {
+		return raw
+	}
+
+	switch rule.kind {
+	case redactNull:
+		return nil
+	case redactHash:
+		return hashValue(raw)
+	case redactFakerEmail:
+		return fakerEmail(r.userID, column)
+	case redactFakerName:
+		return fakerName(r.userID, column)
+	case redactRegex:
+		s, ok := raw.(string)
+		if !ok {
+			if b, ok := raw.([]byte); ok {
+				s = string(b)
+			} else {
+				return raw
+			}
+		}
+		return rule.pattern.ReplaceAllString(s, rule.replacement)
+	default:
+		return raw
+	}
+}
+
+func hashValue(raw interface{}) string {
+	h := sha256.New()
+	h.Write([]byte(*redactSaltFlag))
+	fmt.Fprintf(h, "%v", raw)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// seedUint64 derives a deterministic seed from userID and a discriminator
+// (the column name) so different faker:* columns for the same user don't
+// collide on the same pseudonym.
+func seedUint64(userID, discriminator string) uint64 {
+	h := sha256.Sum256([]byte(userID + "|" + discriminator))
+	var seed uint64
+	for i := 0; i < 8; i++ {
+		seed = seed<<8 | uint64(h[i])
+	}
+	return seed
+}
+
+var fakerFirstNames = []string{"Alex", "Jordan", "Taylor", "Morgan", "Casey", "Riley", "Sam", "Drew", "Avery", "Quinn"}
+var fakerLastNames = []string{"Nguyen", "Smith", "Kim", "Patel", "Garcia", "Müller", "Rossi", "Dubois", "Kovač", "Silva"}
+
+// fakerName produces a deterministic pseudonym for userID, stable across
+// runs and columns (but distinct per-column via the seed discriminator).
+func fakerName(userID, column string) string {
+	seed := seedUint64(userID, column)
+	first := fakerFirstNames[seed%uint64(len(fakerFirstNames))]
+	last := fakerLastNames[(seed/uint64(len(fakerFirstNames)))%uint64(len(fakerLastNames))]
+	return first + " " + last
+}
+
+// fakerEmail produces a deterministic pseudonymous email for userID.
+func fakerEmail(userID, column string) string {
+	seed := seedUint64(userID, column)
+	return "user" + strconv.FormatUint(seed%1_000_000_000, 10) + "@example.invalid"
+}
@@ -0,0 +1,62 @@
+package main
+
+import "testing"
+
+func TestParseRedactionTransformSimple(t *testing.T) {
+	for _, transform := range []string{"hash", "null", "faker:email", "faker:name"} {
+		rule, err := parseRedactionTransform(transform)
+		if err != nil {
+			t.Fatalf("parseRedactionTransform(%q) returned error: %v", transform, err)
+		}
+		if string(rule.kind) != transform {
+			t.Fatalf("parseRedactionTransform(%q) kind = %q", transform, rule.kind)
+		}
+	}
+}
+
+func TestParseRedactionTransformRegex(t *testing.T) {
+	rule, err := parseRedactionTransform("regex:/^foo$/bar/")
+	if err != nil {
+		t.Fatalf("parseRedactionTransform returned error: %v", err)
+	}
+	if rule.kind != redactRegex {
+		t.Fatalf("kind = %q, want %q", rule.kind, redactRegex)
+	}
+	if rule.pattern.String() != "^foo$" {
+		t.Fatalf("pattern = %q, want %q", rule.pattern.String(), "^foo$")
+	}
+	if rule.replacement != "bar" {
+		t.Fatalf("replacement = %q, want %q", rule.replacement, "bar")
+	}
+}
+
+// TestParseRedactionTransformRegexWithSlashes reproduces the bug where a
+// pattern containing a literal "/" (e.g. a URL path) was parsed wrong
+// because the old implementation always split on the first "/". Picking a
+// delimiter that doesn't collide with the pattern sidesteps the ambiguity.
+func TestParseRedactionTransformRegexWithSlashes(t *testing.T) {
+	rule, err := parseRedactionTransform("regex:#^/v1/(.*)$#REDACTED#")
+	if err != nil {
+		t.Fatalf("parseRedactionTransform returned error: %v", err)
+	}
+	if rule.pattern.String() != "^/v1/(.*)$" {
+		t.Fatalf("pattern = %q, want %q", rule.pattern.String(), "^/v1/(.*)$")
+	}
+	if rule.replacement != "REDACTED" {
+		t.Fatalf("replacement = %q, want %q", rule.replacement, "REDACTED")
+	}
+}
+
+func TestParseRedactionTransformRegexMalformed(t *testing.T) {
+	for _, transform := range []string{"regex:", "regex:/", "regex:/onlyone"} {
+		if _, err := parseRedactionTransform(transform); err == nil {
+			t.Fatalf("parseRedactionTransform(%q) expected error, got nil", transform)
+		}
+	}
+}
+
+func TestParseRedactionTransformUnknown(t *testing.T) {
+	if _, err := parseRedactionTransform("uppercase"); err == nil {
+		t.Fatal("expected error for unknown transform, got nil")
+	}
+}